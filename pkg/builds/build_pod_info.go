@@ -0,0 +1,155 @@
+package builds
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// LabelPipeline is the pod/PipelineRun label holding the "owner/repo/branch" pipeline name
+	LabelPipeline = "jenkins.io/pipeline"
+
+	// LabelBuildNumber is the pod/PipelineRun label holding the build number
+	LabelBuildNumber = "build-number"
+)
+
+// BaseBuildInfo is the common view of a build shared by Jenkins/knative-build pods and
+// Tekton PipelineRuns, so that code picking a build to tail logs from doesn't need to
+// care which engine produced it
+type BaseBuildInfo interface {
+	GetBuild() string
+	GetPipeline() string
+	GetOwner() string
+	GetRepository() string
+	GetBranch() string
+}
+
+// BuildPodInfo is the information on a build pod for a knative build
+type BuildPodInfo struct {
+	Pod        *corev1.Pod
+	Pipeline   string
+	Build      string
+	Owner      string
+	Repository string
+	Branch     string
+}
+
+// GetBuild returns the build number
+func (b *BuildPodInfo) GetBuild() string {
+	return b.Build
+}
+
+// GetPipeline returns the pipeline name in the form owner/repository/branch
+func (b *BuildPodInfo) GetPipeline() string {
+	return b.Pipeline
+}
+
+// GetOwner returns the owner (person/organisation) of the repository which triggered the build
+func (b *BuildPodInfo) GetOwner() string {
+	return b.Owner
+}
+
+// GetRepository returns the repository which triggered the build
+func (b *BuildPodInfo) GetRepository() string {
+	return b.Repository
+}
+
+// GetBranch returns the branch which triggered the build
+func (b *BuildPodInfo) GetBranch() string {
+	return b.Branch
+}
+
+// GetBuildPods returns all the build pods in the given namespace
+func GetBuildPods(kubeClient kubernetes.Interface, ns string) ([]*corev1.Pod, error) {
+	podList, err := kubeClient.CoreV1().Pods(ns).List(metav1.ListOptions{
+		LabelSelector: LabelPipeline,
+	})
+	if err != nil {
+		return nil, err
+	}
+	answer := []*corev1.Pod{}
+	for i := range podList.Items {
+		answer = append(answer, &podList.Items[i])
+	}
+	return answer, nil
+}
+
+// CreateBuildPodInfo creates a BuildPodInfo from a pod's labels
+func CreateBuildPodInfo(pod *corev1.Pod) *BuildPodInfo {
+	labels := pod.Labels
+	pipeline := labels[LabelPipeline]
+	owner, repository, branch := SplitPipelineName(pipeline)
+	return &BuildPodInfo{
+		Pod:        pod,
+		Pipeline:   pipeline,
+		Build:      labels[LabelBuildNumber],
+		Owner:      owner,
+		Repository: repository,
+		Branch:     branch,
+	}
+}
+
+// SplitPipelineName splits a pipeline name of the form "owner/repository/branch" into its parts
+func SplitPipelineName(pipeline string) (string, string, string) {
+	parts := strings.Split(pipeline, "/")
+	switch len(parts) {
+	case 3:
+		return parts[0], parts[1], parts[2]
+	case 2:
+		return "", parts[0], parts[1]
+	default:
+		return "", "", pipeline
+	}
+}
+
+// SortBuildPodInfos sorts the given build pod infos with the most recent build first
+func SortBuildPodInfos(infos []*BuildPodInfo) {
+	sort.Slice(infos, func(i, j int) bool {
+		return buildNumberOf(infos[i].Build) > buildNumberOf(infos[j].Build)
+	})
+}
+
+func buildNumberOf(build string) int {
+	n, _ := strconv.Atoi(build)
+	return n
+}
+
+// BuildPodInfoFilter is used to filter the build pods/PipelineRuns which should be displayed
+type BuildPodInfoFilter struct {
+	Pending    bool
+	Filter     string
+	Owner      string
+	Repository string
+	Branch     string
+	Build      string
+}
+
+// BuildNumber returns the build number flag as an int, or 0 if it's not set/not numeric
+func (f *BuildPodInfoFilter) BuildNumber() int {
+	return buildNumberOf(f.Build)
+}
+
+// BuildMatches returns true if the given build matches the filter
+func (f *BuildPodInfoFilter) BuildMatches(info BaseBuildInfo) bool {
+	if f.Owner != "" && f.Owner != info.GetOwner() {
+		return false
+	}
+	if f.Repository != "" && f.Repository != info.GetRepository() {
+		return false
+	}
+	if f.Branch != "" && f.Branch != info.GetBranch() {
+		return false
+	}
+	if f.Build != "" && f.Build != info.GetBuild() {
+		return false
+	}
+	if f.Filter != "" && !strings.Contains(info.GetPipeline(), f.Filter) {
+		return false
+	}
+	return true
+}
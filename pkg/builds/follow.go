@@ -0,0 +1,198 @@
+package builds
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// syncWriter wraps an io.Writer with a mutex so that concurrent writers - e.g. several
+// build pods streamed at once via --follow-all - never interleave a single line's bytes
+type syncWriter struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.out.Write(p)
+}
+
+// FollowBuildsOptions configures the continuous --follow-all build pod streamer used for
+// the non-Tekton (Jenkins/knative-build) engines
+type FollowBuildsOptions struct {
+	// Filter selects which build pods to stream
+	Filter BuildPodInfoFilter
+
+	// Since only streams build pods created after now minus Since; zero means no limit
+	Since time.Duration
+
+	// MaxRuns bounds how many build pods will be streamed; 0 means unbounded
+	MaxRuns int
+
+	// Prefix controls whether each log line is prefixed with "[pod/container]"
+	Prefix bool
+
+	// Color controls whether the prefix is colorized
+	Color bool
+}
+
+// FollowAllBuilds runs a Pods informer over build pods in ns and, for every Add/Update
+// event whose build pod matches opts.Filter, spawns a goroutine that tails that pod's init
+// containers in order to out. Pods are deduplicated by UID, so an Update event (or informer
+// resync) for a pod already being tailed never replays it. The set of seen UIDs is held in
+// memory only, so a restarted command tails every matching pod it lists again.
+// FollowAllBuilds blocks until ctx is cancelled.
+func FollowAllBuilds(ctx context.Context, kubeClient kubernetes.Interface, ns string, out io.Writer, opts FollowBuildsOptions) error {
+	syncOut := &syncWriter{out: out}
+
+	var cutoff time.Time
+	if opts.Since > 0 {
+		cutoff = time.Now().Add(-opts.Since)
+	}
+
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	var runCount int32
+
+	handle := func(obj interface{}) {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			return
+		}
+
+		mu.Lock()
+		alreadySeen := seen[string(pod.UID)]
+		mu.Unlock()
+		if alreadySeen {
+			return
+		}
+
+		if !cutoff.IsZero() && pod.CreationTimestamp.Time.Before(cutoff) {
+			return
+		}
+		if opts.MaxRuns > 0 && int(atomic.LoadInt32(&runCount)) >= opts.MaxRuns {
+			return
+		}
+		if len(pod.Spec.InitContainers) == 0 {
+			return
+		}
+
+		buildInfo := CreateBuildPodInfo(pod)
+		if !opts.Filter.BuildMatches(buildInfo) {
+			return
+		}
+		if opts.MaxRuns > 0 && int(atomic.AddInt32(&runCount, 1)) > opts.MaxRuns {
+			return
+		}
+
+		// Only now are we committed to streaming this pod, so only now record it as seen:
+		// marking it earlier would mean the filter/init-container checks above permanently
+		// drop a pod if it's checked again before it's ready.
+		mu.Lock()
+		alreadySeen = seen[string(pod.UID)]
+		seen[string(pod.UID)] = true
+		mu.Unlock()
+		if alreadySeen {
+			return
+		}
+
+		log.Infof("streaming logs for %s\n", util.ColorInfo(buildInfo.Pipeline+" #"+buildInfo.Build))
+		go followBuildPod(ctx, kubeClient, ns, pod.Name, syncOut, opts)
+	}
+
+	listWatch := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = LabelPipeline
+			return kubeClient.CoreV1().Pods(ns).List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = LabelPipeline
+			return kubeClient.CoreV1().Pods(ns).Watch(options)
+		},
+	}
+	informer := cache.NewSharedInformer(listWatch, &corev1.Pod{}, 30*time.Second)
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    handle,
+		UpdateFunc: func(old, newObj interface{}) { handle(newObj) },
+	})
+
+	stopCh := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		close(stopCh)
+	}()
+	informer.Run(stopCh)
+	return nil
+}
+
+// followBuildPod tails every init container of the named pod, in declaration order,
+// stopping early if ctx is cancelled
+func followBuildPod(ctx context.Context, kubeClient kubernetes.Interface, ns string, podName string, out io.Writer, opts FollowBuildsOptions) {
+	pod, err := kubeClient.CoreV1().Pods(ns).Get(podName, metav1.GetOptions{})
+	if err != nil {
+		log.Warnf("failed to load pod %s: %s\n", podName, err)
+		return
+	}
+	for _, container := range pod.Spec.InitContainers {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		if err := tailBuildContainer(ctx, kubeClient, ns, podName, container, out, opts); err != nil {
+			log.Warnf("error tailing %s/%s: %s\n", podName, container.Name, err)
+			return
+		}
+	}
+}
+
+func tailBuildContainer(ctx context.Context, kubeClient kubernetes.Interface, ns string, podName string, container corev1.Container, out io.Writer, opts FollowBuildsOptions) error {
+	req := kubeClient.CoreV1().Pods(ns).GetLogs(podName, &corev1.PodLogOptions{
+		Container: container.Name,
+		Follow:    true,
+	})
+	stream, err := req.Stream()
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			stream.Close()
+		case <-done:
+		}
+	}()
+	defer close(done)
+
+	prefix := ""
+	if opts.Prefix {
+		label := fmt.Sprintf("[%s/%s] ", podName, container.Name)
+		if opts.Color {
+			label = fmt.Sprintf("%s", util.ColorInfo(label))
+		}
+		prefix = label
+	}
+
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		fmt.Fprintf(out, "%s%s\n", prefix, scanner.Text())
+	}
+	return scanner.Err()
+}
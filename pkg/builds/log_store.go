@@ -0,0 +1,94 @@
+package builds
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// ManifestFileName is the name of the manifest file written alongside a build's archived logs
+const ManifestFileName = "manifest.json"
+
+// LogManifest describes the stages and exit codes of a build whose logs have been archived
+type LogManifest struct {
+	Owner      string             `json:"owner"`
+	Repository string             `json:"repository"`
+	Branch     string             `json:"branch"`
+	Build      string             `json:"build"`
+	Stages     []LogManifestStage `json:"stages"`
+}
+
+// LogManifestStage describes a single archived stage
+type LogManifestStage struct {
+	Name       string                 `json:"name"`
+	Containers []LogManifestContainer `json:"containers"`
+}
+
+// LogManifestContainer describes a single archived init container's outcome
+type LogManifestContainer struct {
+	Name     string `json:"name"`
+	ExitCode int32  `json:"exitCode"`
+}
+
+// LogStore persists and replays completed build logs once the pod that produced them has
+// been garbage collected. Keys are always of the form {owner}/{repo}/{branch}/{build}/...
+// so that a LogStore implementation only needs to know how to read/write blobs by path.
+type LogStore interface {
+	// WriteLog uploads a single stage/container's log
+	WriteLog(owner, repository, branch, build, stage, container string, r io.Reader) error
+
+	// WriteManifest uploads the manifest describing every stage/container archived for a build
+	WriteManifest(owner, repository, branch, build string, manifest *LogManifest) error
+
+	// ReadLog streams back a previously archived stage/container's log
+	ReadLog(owner, repository, branch, build, stage, container string) (io.ReadCloser, error)
+
+	// ReadManifest returns the manifest previously written for a build, if any
+	ReadManifest(owner, repository, branch, build string) (*LogManifest, error)
+}
+
+// LogPath builds the conventional object storage key for a build log or manifest file
+func LogPath(owner, repository, branch, build string, parts ...string) string {
+	all := append([]string{owner, repository, branch, build}, parts...)
+	return strings.Join(all, "/")
+}
+
+// CreateLogStore creates a LogStore implementation from a storage URL, supporting
+// s3://bucket[/prefix], gs://bucket[/prefix] and file://path (or a bare filesystem path)
+func CreateLogStore(storageURL string) (LogStore, error) {
+	if storageURL == "" {
+		return nil, fmt.Errorf("no storage URL configured for build log archival")
+	}
+	u, err := url.Parse(storageURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage URL %s: %s", storageURL, err)
+	}
+	switch u.Scheme {
+	case "s3":
+		return NewS3LogStore(u.Host, strings.TrimPrefix(u.Path, "/")), nil
+	case "gs":
+		return NewGCSLogStore(u.Host, strings.TrimPrefix(u.Path, "/")), nil
+	case "file", "":
+		root := u.Path
+		if root == "" {
+			root = storageURL
+		}
+		return NewFileSystemLogStore(root), nil
+	default:
+		return nil, fmt.Errorf("unsupported storage scheme %q in %s", u.Scheme, storageURL)
+	}
+}
+
+func marshalManifest(manifest *LogManifest) ([]byte, error) {
+	return json.MarshalIndent(manifest, "", "  ")
+}
+
+func unmarshalManifest(data []byte) (*LogManifest, error) {
+	manifest := &LogManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
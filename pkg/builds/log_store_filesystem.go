@@ -0,0 +1,70 @@
+package builds
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// FileSystemLogStore is a LogStore backed by a local (or mounted network) directory tree,
+// handy for development or for clusters which archive logs onto a shared volume
+type FileSystemLogStore struct {
+	RootDir string
+}
+
+// NewFileSystemLogStore creates a LogStore rooted at the given directory
+func NewFileSystemLogStore(rootDir string) *FileSystemLogStore {
+	return &FileSystemLogStore{RootDir: rootDir}
+}
+
+// WriteLog writes the log to {root}/{owner}/{repo}/{branch}/{build}/{stage}/{container}.log
+func (s *FileSystemLogStore) WriteLog(owner, repository, branch, build, stage, container string, r io.Reader) error {
+	path := filepath.Join(s.RootDir, owner, repository, branch, build, stage, container+".log")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory for %s", path)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", path)
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, r)
+	return err
+}
+
+// WriteManifest writes the manifest to {root}/{owner}/{repo}/{branch}/{build}/manifest.json
+func (s *FileSystemLogStore) WriteManifest(owner, repository, branch, build string, manifest *LogManifest) error {
+	data, err := marshalManifest(manifest)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal manifest")
+	}
+	path := filepath.Join(s.RootDir, owner, repository, branch, build, ManifestFileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory for %s", path)
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// ReadLog reads the log back from {root}/{owner}/{repo}/{branch}/{build}/{stage}/{container}.log
+func (s *FileSystemLogStore) ReadLog(owner, repository, branch, build, stage, container string) (io.ReadCloser, error) {
+	path := filepath.Join(s.RootDir, owner, repository, branch, build, stage, container+".log")
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open archived log %s", path)
+	}
+	return file, nil
+}
+
+// ReadManifest reads the manifest back from {root}/{owner}/{repo}/{branch}/{build}/manifest.json
+func (s *FileSystemLogStore) ReadManifest(owner, repository, branch, build string) (*LogManifest, error) {
+	path := filepath.Join(s.RootDir, owner, repository, branch, build, ManifestFileName)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read manifest %s", path)
+	}
+	return unmarshalManifest(data)
+}
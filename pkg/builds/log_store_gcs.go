@@ -0,0 +1,110 @@
+package builds
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+
+	"cloud.google.com/go/storage"
+	"github.com/pkg/errors"
+)
+
+// GCSLogStore is a LogStore backed by a Google Cloud Storage bucket
+type GCSLogStore struct {
+	Bucket string
+	Prefix string
+}
+
+// NewGCSLogStore creates a LogStore backed by the given GCS bucket/prefix
+func NewGCSLogStore(bucket string, prefix string) *GCSLogStore {
+	return &GCSLogStore{Bucket: bucket, Prefix: prefix}
+}
+
+func (s *GCSLogStore) object(ctx context.Context, client *storage.Client, name string) *storage.ObjectHandle {
+	return client.Bucket(s.Bucket).Object(name)
+}
+
+func (s *GCSLogStore) objectName(parts ...string) string {
+	name := LogPath(parts[0], parts[1], parts[2], parts[3], parts[4:]...)
+	if s.Prefix == "" {
+		return name
+	}
+	return s.Prefix + "/" + name
+}
+
+func (s *GCSLogStore) write(ctx context.Context, name string, data []byte) error {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create GCS client")
+	}
+	defer client.Close()
+
+	w := s.object(ctx, client, name).NewWriter(ctx)
+	if _, err := w.Write(data); err != nil {
+		return errors.Wrapf(err, "failed to write gs://%s/%s", s.Bucket, name)
+	}
+	return w.Close()
+}
+
+func (s *GCSLogStore) read(ctx context.Context, name string) (io.ReadCloser, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create GCS client")
+	}
+	r, err := s.object(ctx, client, name).NewReader(ctx)
+	if err != nil {
+		client.Close()
+		return nil, errors.Wrapf(err, "failed to read gs://%s/%s", s.Bucket, name)
+	}
+	return &gcsReadCloser{ReadCloser: r, client: client}, nil
+}
+
+// gcsReadCloser closes both the object reader and the client it was opened from
+type gcsReadCloser struct {
+	io.ReadCloser
+	client *storage.Client
+}
+
+func (r *gcsReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	r.client.Close()
+	return err
+}
+
+// WriteLog uploads the log to gs://{bucket}/{prefix}/{owner}/{repo}/{branch}/{build}/{stage}/{container}.log
+func (s *GCSLogStore) WriteLog(owner, repository, branch, build, stage, container string, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return errors.Wrap(err, "failed to read log")
+	}
+	return s.write(context.Background(), s.objectName(owner, repository, branch, build, stage, container+".log"), data)
+}
+
+// WriteManifest uploads the manifest to gs://{bucket}/{prefix}/{owner}/{repo}/{branch}/{build}/manifest.json
+func (s *GCSLogStore) WriteManifest(owner, repository, branch, build string, manifest *LogManifest) error {
+	data, err := marshalManifest(manifest)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal manifest")
+	}
+	return s.write(context.Background(), s.objectName(owner, repository, branch, build, ManifestFileName), data)
+}
+
+// ReadLog downloads the log from gs://{bucket}/{prefix}/{owner}/{repo}/{branch}/{build}/{stage}/{container}.log
+func (s *GCSLogStore) ReadLog(owner, repository, branch, build, stage, container string) (io.ReadCloser, error) {
+	return s.read(context.Background(), s.objectName(owner, repository, branch, build, stage, container+".log"))
+}
+
+// ReadManifest downloads the manifest from gs://{bucket}/{prefix}/{owner}/{repo}/{branch}/{build}/manifest.json
+func (s *GCSLogStore) ReadManifest(owner, repository, branch, build string) (*LogManifest, error) {
+	r, err := s.read(context.Background(), s.objectName(owner, repository, branch, build, ManifestFileName))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read manifest")
+	}
+	return unmarshalManifest(data)
+}
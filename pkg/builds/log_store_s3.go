@@ -0,0 +1,116 @@
+package builds
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+)
+
+// S3LogStore is a LogStore backed by an AWS S3 bucket
+type S3LogStore struct {
+	Bucket string
+	Prefix string
+}
+
+// NewS3LogStore creates a LogStore backed by the given S3 bucket/prefix
+func NewS3LogStore(bucket string, prefix string) *S3LogStore {
+	return &S3LogStore{Bucket: bucket, Prefix: prefix}
+}
+
+func (s *S3LogStore) client() (*s3.S3, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create AWS session")
+	}
+	return s3.New(sess), nil
+}
+
+func (s *S3LogStore) key(parts ...string) string {
+	if s.Prefix == "" {
+		return LogPath(parts[0], parts[1], parts[2], parts[3], parts[4:]...)
+	}
+	return s.Prefix + "/" + LogPath(parts[0], parts[1], parts[2], parts[3], parts[4:]...)
+}
+
+// WriteLog uploads the log to s3://{bucket}/{prefix}/{owner}/{repo}/{branch}/{build}/{stage}/{container}.log
+func (s *S3LogStore) WriteLog(owner, repository, branch, build, stage, container string, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return errors.Wrap(err, "failed to read log")
+	}
+	client, err := s.client()
+	if err != nil {
+		return err
+	}
+	key := s.key(owner, repository, branch, build, stage, container+".log")
+	_, err = client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	return errors.Wrapf(err, "failed to upload log to s3://%s/%s", s.Bucket, key)
+}
+
+// WriteManifest uploads the manifest to s3://{bucket}/{prefix}/{owner}/{repo}/{branch}/{build}/manifest.json
+func (s *S3LogStore) WriteManifest(owner, repository, branch, build string, manifest *LogManifest) error {
+	data, err := marshalManifest(manifest)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal manifest")
+	}
+	client, err := s.client()
+	if err != nil {
+		return err
+	}
+	key := s.key(owner, repository, branch, build, ManifestFileName)
+	_, err = client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	return errors.Wrapf(err, "failed to upload manifest to s3://%s/%s", s.Bucket, key)
+}
+
+// ReadLog downloads the log from s3://{bucket}/{prefix}/{owner}/{repo}/{branch}/{build}/{stage}/{container}.log
+func (s *S3LogStore) ReadLog(owner, repository, branch, build, stage, container string) (io.ReadCloser, error) {
+	client, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+	key := s.key(owner, repository, branch, build, stage, container+".log")
+	out, err := client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download log from s3://%s/%s", s.Bucket, key)
+	}
+	return out.Body, nil
+}
+
+// ReadManifest downloads the manifest from s3://{bucket}/{prefix}/{owner}/{repo}/{branch}/{build}/manifest.json
+func (s *S3LogStore) ReadManifest(owner, repository, branch, build string) (*LogManifest, error) {
+	client, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+	key := s.key(owner, repository, branch, build, ManifestFileName)
+	out, err := client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download manifest from s3://%s/%s", s.Bucket, key)
+	}
+	defer out.Body.Close()
+
+	data, err := ioutil.ReadAll(out.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read manifest")
+	}
+	return unmarshalManifest(data)
+}
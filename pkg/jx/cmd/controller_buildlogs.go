@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/jx/pkg/builds"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/tekton"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// ControllerBuildLogsOptions are the flags for the build log archiving controller
+type ControllerBuildLogsOptions struct {
+	CommonOptions
+
+	ArchiveURL       string
+	TektonAPIVersion string
+}
+
+var (
+	controllerBuildLogsLong = templates.LongDesc(`
+		Archives the logs of every completed Tekton PipelineRun to object storage, so they
+		can still be replayed with 'jx get build log --from-storage' once the build's pod
+		has been garbage collected.
+`)
+
+	controllerBuildLogsExample = templates.Examples(`
+		# Archive completed build logs to the configured storage location
+		jx controller buildlogs
+
+		# Archive completed build logs to a specific bucket, overriding the team settings
+		jx controller buildlogs --archive-url s3://my-bucket
+	`)
+)
+
+// NewCmdControllerBuildLogs creates the command
+//
+// This is intended to be registered as a subcommand of 'jx controller' (alongside the
+// other long-running controllers), the same way NewCmdGetBuildLogs is registered under
+// 'jx get'; that parent command isn't part of this checkout.
+func NewCmdControllerBuildLogs(commonOpts *CommonOptions) *cobra.Command {
+	options := &ControllerBuildLogsOptions{
+		CommonOptions: *commonOpts,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "buildlogs",
+		Short:   "Archives completed Tekton PipelineRun logs to object storage",
+		Long:    controllerBuildLogsLong,
+		Example: controllerBuildLogsExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.ArchiveURL, "archive-url", "", "", "Overrides the object storage URL (s3://, gs:// or file://) that completed build logs are archived to")
+	cmd.Flags().StringVarP(&options.TektonAPIVersion, "tekton-api-version", "", "", "Overrides the tekton.dev PipelineRun API version to use (v1alpha1 or v1beta1) instead of auto-detecting it via discovery")
+	return cmd
+}
+
+// Run implements this command
+func (o *ControllerBuildLogsOptions) Run() error {
+	ctx, cancel := contextWithInterrupt()
+	defer cancel()
+
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
+	tektonClient, _, err := o.TektonClient()
+	if err != nil {
+		return err
+	}
+
+	devEnv, err := kube.GetEnrichedDevEnvironment(kubeClient, jxClient, ns)
+	if err != nil {
+		return err
+	}
+	archiveURL := resolveArchiveURL(o.ArchiveURL, devEnv)
+	if archiveURL == "" {
+		return fmt.Errorf("no storage URL configured to archive logs to: set --archive-url or configure a 'logs' storage location on the team settings")
+	}
+	store, err := builds.CreateLogStore(archiveURL)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("archiving completed PipelineRun logs to %s\n", util.ColorInfo(archiveURL))
+	controller := tekton.NewLogPersistController(kubeClient, tektonClient, jxClient, ns, store)
+	controller.TektonAPIVersion = o.TektonAPIVersion
+	return controller.Run(ctx)
+}
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"sort"
 	"strings"
 	"time"
@@ -14,6 +17,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/tekton"
+	"github.com/jenkins-x/jx/pkg/util/backoff"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/kubernetes"
@@ -21,7 +25,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/util"
-	tektonclient "github.com/knative/build-pipeline/pkg/client/clientset/versioned"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -37,6 +41,38 @@ type GetBuildLogsOptions struct {
 	JenkinsSelector         JenkinsSelectorOptions
 	CurrentFolder           bool
 	WaitForPipelineDuration time.Duration
+	TektonAPIVersion        string
+	LogPrefix               bool
+	NoColor                 bool
+	MaxConcurrency          int
+	ArchiveURL              string
+	FromStorage             bool
+	FollowAll               bool
+	Since                   time.Duration
+	MaxRuns                 int
+	Output                  string
+
+	ctx context.Context
+}
+
+// contextWithInterrupt returns a context which is cancelled as soon as the process receives
+// an interrupt (Ctrl-C), so long-running waits/retries/streams can stop promptly instead of
+// running to their full timeout
+func contextWithInterrupt() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	go func() {
+		select {
+		case <-sigCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(sigCh)
+		cancel()
+	}
 }
 
 var (
@@ -95,6 +131,16 @@ func NewCmdGetBuildLogs(commonOpts *CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.BuildFilter.Branch, "branch", "", "", "Filters the branch")
 	cmd.Flags().StringVarP(&options.BuildFilter.Build, "build", "", "", "The build number to view")
 	cmd.Flags().BoolVarP(&options.CurrentFolder, "current", "c", false, "Display logs using current folder as repo name, and parent folder as owner")
+	cmd.Flags().StringVarP(&options.TektonAPIVersion, "tekton-api-version", "", "", "Overrides the tekton.dev PipelineRun API version to use (v1alpha1 or v1beta1) instead of auto-detecting it via discovery")
+	cmd.Flags().BoolVarP(&options.LogPrefix, "log-prefix", "", true, "Prefixes each log line with the [stage/container] it came from, useful when stages stream in parallel")
+	cmd.Flags().BoolVarP(&options.NoColor, "no-color", "", false, "Disables colorizing the [stage/container] log prefix")
+	cmd.Flags().IntVarP(&options.MaxConcurrency, "max-concurrency", "", 4, "The maximum number of stages to tail concurrently")
+	cmd.Flags().StringVarP(&options.ArchiveURL, "archive-url", "", "", "Overrides the object storage URL (s3://, gs:// or file://) used to replay archived logs once the build's pod is gone")
+	cmd.Flags().BoolVarP(&options.FromStorage, "from-storage", "", false, "Always replay the build log from archived storage instead of tailing the live pod")
+	cmd.Flags().BoolVarP(&options.FollowAll, "follow-all", "", false, "Continuously streams the logs of every matching PipelineRun as it starts, rather than picking a single build")
+	cmd.Flags().DurationVarP(&options.Since, "since", "", 0, "Only used with --follow-all: ignores PipelineRuns created before now minus this duration")
+	cmd.Flags().IntVarP(&options.MaxRuns, "max-runs", "", 0, "Only used with --follow-all: the maximum number of PipelineRuns to stream; later runs are ignored while the command keeps running, 0 means unbounded")
+	cmd.Flags().StringVarP(&options.Output, "output", "", "", "Changes the output format: 'json' streams newline-delimited JSON events instead of raw log lines, 'junit' writes a JUnit XML report summarising stage/container outcomes")
 	options.JenkinsSelector.AddFlags(cmd)
 
 	return cmd
@@ -102,6 +148,10 @@ func NewCmdGetBuildLogs(commonOpts *CommonOptions) *cobra.Command {
 
 // Run implements this command
 func (o *GetBuildLogsOptions) Run() error {
+	ctx, cancel := contextWithInterrupt()
+	defer cancel()
+	o.ctx = ctx
+
 	jxClient, ns, err := o.JXClientAndDevNamespace()
 	if err != nil {
 		return err
@@ -120,10 +170,44 @@ func (o *GetBuildLogsOptions) Run() error {
 		return err
 	}
 
+	if o.FollowAll {
+		if o.Output != "" {
+			return fmt.Errorf("--output is not yet supported together with --follow-all")
+		}
+		if !tektonEnabled {
+			return builds.FollowAllBuilds(o.ctx, kubeClient, ns, o.Out, builds.FollowBuildsOptions{
+				Filter:  o.BuildFilter,
+				Since:   o.Since,
+				MaxRuns: o.MaxRuns,
+				Prefix:  o.LogPrefix,
+				Color:   !o.NoColor,
+			})
+		}
+		return tekton.FollowAll(o.ctx, kubeClient, tektonClient, jxClient, ns, o.Out, tekton.FollowAllOptions{
+			Filter:           o.BuildFilter,
+			Since:            o.Since,
+			MaxRuns:          o.MaxRuns,
+			TektonAPIVersion: o.TektonAPIVersion,
+			Stream: tekton.StreamOptions{
+				Prefix:         o.LogPrefix,
+				Color:          !o.NoColor,
+				MaxConcurrency: o.MaxConcurrency,
+				PodWaitTimeout: o.WaitForPipelineDuration,
+			},
+		})
+	}
+
 	devEnv, err := kube.GetEnrichedDevEnvironment(kubeClient, jxClient, ns)
+	if err != nil {
+		return err
+	}
 	webhookEngine := devEnv.Spec.WebHookEngine
 	if webhookEngine == v1.WebHookEngineProw && !o.JenkinsSelector.IsCustom() {
-		return o.getProwBuildLog(kubeClient, tektonClient, jxClient, ns, tektonEnabled)
+		return o.getProwBuildLog(kubeClient, tektonClient, jxClient, ns, tektonEnabled, devEnv)
+	}
+
+	if o.Output != "" {
+		return fmt.Errorf("--output is only supported for Tekton builds")
 	}
 
 	args := o.Args
@@ -211,7 +295,8 @@ func (o *GetBuildLogsOptions) getLastJenkinsBuild(name string, buildNumber int)
 	}
 
 	if o.Wait {
-		err := o.retry(60, time.Second*2, f)
+		b := &backoff.Backoff{Min: time.Second, Max: time.Second * 10, Factor: 2, Jitter: true}
+		err := retryWithBackoff(o.ctx, time.Minute*2, b, f)
 		return last, err
 	} else {
 		err := f()
@@ -219,7 +304,33 @@ func (o *GetBuildLogsOptions) getLastJenkinsBuild(name string, buildNumber int)
 	}
 }
 
-func (o *GetBuildLogsOptions) getProwBuildLog(kubeClient kubernetes.Interface, tektonClient tektonclient.Interface, jxClient versioned.Interface, ns string, tektonEnabled bool) error {
+// retryWithBackoff calls f until it succeeds, ctx is cancelled, or maxElapsed has passed
+// since the first attempt (maxElapsed <= 0 means retry forever until ctx is cancelled),
+// sleeping for b.Duration() between attempts and logging once per attempt
+func retryWithBackoff(ctx context.Context, maxElapsed time.Duration, b *backoff.Backoff, f func() error) error {
+	start := time.Now()
+	attempt := 0
+	for {
+		attempt++
+		err := f()
+		if err == nil {
+			return nil
+		}
+		if maxElapsed > 0 && time.Since(start) >= maxElapsed {
+			return err
+		}
+
+		d := b.Duration()
+		log.Infof("attempt %d failed: %s - retrying in %s\n", attempt, err, d)
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (o *GetBuildLogsOptions) getProwBuildLog(kubeClient kubernetes.Interface, tektonClient tektonclient.Interface, jxClient versioned.Interface, ns string, tektonEnabled bool, devEnv *v1.Environment) error {
 	if o.CurrentFolder {
 		currentDirectory, err := os.Getwd()
 		if err != nil {
@@ -266,6 +377,9 @@ func (o *GetBuildLogsOptions) getProwBuildLog(kubeClient kubernetes.Interface, t
 		return fmt.Errorf("No pipeline chosen")
 	}
 	name := args[0]
+	if o.FromStorage {
+		return o.replayFromLogStore(name, devEnv)
+	}
 	build := buildMap[name]
 	suffix := ""
 	if build == nil {
@@ -301,69 +415,42 @@ func (o *GetBuildLogsOptions) getProwBuildLog(kubeClient kubernetes.Interface, t
 			}
 			return nil
 		}
-		err := util.Retry(o.WaitForPipelineDuration, f)
+		b := &backoff.Backoff{Min: time.Second, Max: time.Second * 15, Factor: 2, Jitter: true}
+		err := retryWithBackoff(o.ctx, o.WaitForPipelineDuration, b, f)
 		if err != nil {
 			return err
 		}
 	}
 	if build == nil {
+		if resolveArchiveURL(o.ArchiveURL, devEnv) != "" {
+			return o.replayFromLogStore(name, devEnv)
+		}
 		return fmt.Errorf("No Pipeline found for name %s in values: %s", name, strings.Join(names, ", "))
 	}
 
 	if tektonEnabled {
 		pr := build.(*tekton.PipelineRunInfo)
-		log.Infof("Build logs for %s\n", util.ColorInfo(name+suffix))
-		for _, stage := range pr.GetOrderedTaskStages() {
-			if stage.Pod == nil {
-				// The stage's pod hasn't been created yet, so let's wait a bit.
-				f := func() error {
-					if err := stage.SetPodsForStageInfo(kubeClient, tektonClient, ns, pr.PipelineRun); err != nil {
-						return err
-					}
 
-					if stage.Pod == nil {
-						log.Infof("no pod found yet for stage %s in build %s\n", util.ColorInfo(stage.Name), util.ColorInfo(pr.PipelineRun))
-						return fmt.Errorf("No pod for stage %s in build %s exists yet", stage.Name, pr.PipelineRun)
-					}
-
-					return nil
-				}
-				err := util.Retry(o.WaitForPipelineDuration, f)
-				if err != nil {
-					return err
-				}
-			}
-			pod := stage.Pod
-			initContainers := pod.Spec.InitContainers
-			if len(initContainers) <= 0 {
-				return fmt.Errorf("No InitContainers for Pod %s for build: %s", pod.Name, name)
-			}
-			for i, ic := range initContainers {
-				pod, err := kubeClient.CoreV1().Pods(ns).Get(pod.Name, metav1.GetOptions{})
-				if err != nil {
-					return errors.Wrapf(err, "failed to find pod %s", pod.Name)
-				}
-				if i > 0 {
-					icStatuses := pod.Status.InitContainerStatuses
-					if i < len(icStatuses) {
-						lastContainer := icStatuses[i-1]
-						terminated := lastContainer.State.Terminated
-						if terminated != nil && terminated.ExitCode != 0 {
-							log.Warnf("container %s failed with exit code %d: %s\n", lastContainer.Name, terminated.ExitCode, terminated.Message)
-						}
-					}
-				}
-				pod, err = waitForInitContainerToStart(kubeClient, ns, pod, i)
-				if err != nil {
-					return err
-				}
-				err = o.getStageLog(ns, name+suffix, stage.GetStageNameIncludingParents(), pod, ic)
-				if err != nil {
-					return err
-				}
-			}
+		switch o.Output {
+		case "json":
+			return tekton.WriteJSONLog(o.ctx, kubeClient, tektonClient, ns, pr, o.Out, o.WaitForPipelineDuration)
+		case "junit":
+			return tekton.WriteJUnitReport(o.ctx, kubeClient, tektonClient, ns, pr, o.Out, o.WaitForPipelineDuration)
+		case "":
+			log.Infof("Build logs for %s\n", util.ColorInfo(name+suffix))
+			return tekton.StreamStages(o.ctx, kubeClient, tektonClient, ns, pr, o.Out, tekton.StreamOptions{
+				Prefix:         o.LogPrefix,
+				Color:          !o.NoColor,
+				MaxConcurrency: o.MaxConcurrency,
+				PodWaitTimeout: o.WaitForPipelineDuration,
+			})
+		default:
+			return fmt.Errorf("unsupported --output %q, expected 'json' or 'junit'", o.Output)
 		}
 	} else {
+		if o.Output != "" {
+			return fmt.Errorf("--output is only supported for Tekton builds")
+		}
 		b := build.(*builds.BuildPodInfo)
 		pod := b.Pod
 		if pod == nil {
@@ -390,7 +477,7 @@ func (o *GetBuildLogsOptions) getProwBuildLog(kubeClient kubernetes.Interface, t
 					}
 				}
 			}
-			pod, err = waitForInitContainerToStart(kubeClient, ns, pod, i)
+			pod, err = waitForInitContainerToStart(o.ctx, kubeClient, ns, pod, i)
 			if err != nil {
 				return err
 			}
@@ -403,7 +490,7 @@ func (o *GetBuildLogsOptions) getProwBuildLog(kubeClient kubernetes.Interface, t
 	return nil
 }
 
-func waitForInitContainerToStart(kubeClient kubernetes.Interface, ns string, pod *corev1.Pod, idx int) (*corev1.Pod, error) {
+func waitForInitContainerToStart(ctx context.Context, kubeClient kubernetes.Interface, ns string, pod *corev1.Pod, idx int) (*corev1.Pod, error) {
 	if pod.Status.Phase == corev1.PodFailed {
 		log.Warnf("pod %s has failed\n", pod.Name)
 		return pod, nil
@@ -415,9 +502,18 @@ func waitForInitContainerToStart(kubeClient kubernetes.Interface, ns string, pod
 	if idx < len(pod.Spec.InitContainers) {
 		containerName = pod.Spec.InitContainers[idx].Name
 	}
-	log.Infof("waiting for pod %s init container %s to start...\n", util.ColorInfo(pod.Name), util.ColorInfo(containerName))
+
+	b := &backoff.Backoff{Min: 500 * time.Millisecond, Max: 10 * time.Second, Factor: 2, Jitter: true}
+	attempt := 0
 	for {
-		time.Sleep(time.Second)
+		attempt++
+		log.Infof("waiting for pod %s init container %s to start (attempt %d)...\n", util.ColorInfo(pod.Name), util.ColorInfo(containerName), attempt)
+
+		select {
+		case <-time.After(b.Duration()):
+		case <-ctx.Done():
+			return pod, ctx.Err()
+		}
 
 		p, err := kubeClient.CoreV1().Pods(ns).Get(pod.Name, metav1.GetOptions{})
 		if err != nil {
@@ -426,6 +522,7 @@ func waitForInitContainerToStart(kubeClient kubernetes.Interface, ns string, pod
 		if kube.HasInitContainerStarted(p, idx) {
 			return p, nil
 		}
+		pod = p
 	}
 }
 
@@ -434,9 +531,67 @@ func (o *GetBuildLogsOptions) getPodLog(ns string, pod *corev1.Pod, container co
 	return o.TailLogs(ns, pod.Name, container.Name)
 }
 
-func (o *GetBuildLogsOptions) getStageLog(ns, build, stageName string, pod *corev1.Pod, container corev1.Container) error {
-	log.Infof("getting the log for build %s stage %s and init container %s\n", util.ColorInfo(build), util.ColorInfo(stageName), util.ColorInfo(container.Name))
-	return o.TailLogs(ns, pod.Name, container.Name)
+// resolveArchiveURL returns the object storage URL used to archive/replay build logs: the
+// --archive-url flag overrides whatever "logs" storage location is configured on the
+// team's dev environment, which is the same storage location the archiving controller
+// (see ControllerBuildLogsOptions) writes to
+func resolveArchiveURL(override string, devEnv *v1.Environment) string {
+	if override != "" {
+		return override
+	}
+	if devEnv == nil {
+		return ""
+	}
+	return devEnv.Spec.TeamSettings.StorageLocationOrDefault("logs").BucketURL
+}
+
+// replayFromLogStore replays a build's logs from archived object storage rather than
+// tailing a live pod, so that `jx get build log` keeps working after the pod (and its
+// emptyDir-backed logs) has been garbage collected
+func (o *GetBuildLogsOptions) replayFromLogStore(name string, devEnv *v1.Environment) error {
+	if o.Output != "" {
+		return fmt.Errorf("--output is not yet supported together with --from-storage/--archive-url")
+	}
+
+	archiveURL := resolveArchiveURL(o.ArchiveURL, devEnv)
+	if archiveURL == "" {
+		return fmt.Errorf("no storage URL configured to replay logs for %s: set --archive-url or configure a 'logs' storage location on the team settings", name)
+	}
+
+	store, err := builds.CreateLogStore(archiveURL)
+	if err != nil {
+		return err
+	}
+
+	owner := o.BuildFilter.Owner
+	repository := o.BuildFilter.Repository
+	branch := o.BuildFilter.Branch
+	build := o.BuildFilter.Build
+	if repository == "" || build == "" {
+		return fmt.Errorf("--repo and --build must be specified to replay archived logs for %s", name)
+	}
+
+	manifest, err := store.ReadManifest(owner, repository, branch, build)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Replaying archived logs for %s\n", util.ColorInfo(name))
+	for _, stage := range manifest.Stages {
+		for _, container := range stage.Containers {
+			log.Infof("--- stage %s container %s (exit code %d) ---\n", util.ColorInfo(stage.Name), util.ColorInfo(container.Name), container.ExitCode)
+			r, err := store.ReadLog(owner, repository, branch, build, stage.Name, container.Name)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(o.Out, r)
+			r.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 func (o *GetBuildLogsOptions) loadBuilds(kubeClient kubernetes.Interface, ns string) ([]string, string, map[string]builds.BaseBuildInfo, map[string]builds.BaseBuildInfo, error) {
@@ -485,18 +640,23 @@ func (o *GetBuildLogsOptions) loadPipelines(kubeClient kubernetes.Interface, tek
 	buildMap := map[string]builds.BaseBuildInfo{}
 	pipelineMap := map[string]builds.BaseBuildInfo{}
 
-	prList, err := tektonClient.TektonV1alpha1().PipelineRuns(ns).List(metav1.ListOptions{})
+	apiVersion, err := tekton.DetectAPIVersion(kubeClient, o.TektonAPIVersion)
+	if err != nil {
+		log.Warnf("Failed to detect tekton.dev API version %s\n", err)
+		return names, defaultName, buildMap, pipelineMap, err
+	}
 
+	prNames, err := tekton.ListPipelineRunNamesForVersion(tektonClient, ns, apiVersion)
 	if err != nil {
-		log.Warnf("Failed to query PipelineRuns %s\n", err)
+		log.Warnf("Failed to query %s PipelineRuns %s\n", apiVersion, err)
 		return names, defaultName, buildMap, pipelineMap, err
 	}
 
 	buildInfos := []*tekton.PipelineRunInfo{}
-	for _, pr := range prList.Items {
-		pri, err := tekton.CreatePipelineRunInfo(kubeClient, tektonClient, jxClient, ns, pr.Name)
+	for _, prName := range prNames {
+		pri, err := tekton.CreatePipelineRunInfoForVersion(kubeClient, tektonClient, jxClient, ns, prName, apiVersion)
 		if err != nil {
-			log.Warnf("Error creating PipelineRunInfo for PipelineRun %s: %s\n", pr.Name, err)
+			log.Warnf("Error creating PipelineRunInfo for PipelineRun %s: %s\n", prName, err)
 			return names, defaultName, buildMap, pipelineMap, err
 		}
 		if pri != nil {
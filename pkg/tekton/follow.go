@@ -0,0 +1,236 @@
+package tekton
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/builds"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	tektonv1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+)
+
+// terminationPollInterval is how often a followed PipelineRun is checked for completion
+const terminationPollInterval = 2 * time.Second
+
+// FollowAllOptions configures the continuous --follow-all PipelineRun streamer
+type FollowAllOptions struct {
+	// Filter selects which PipelineRuns to stream
+	Filter builds.BuildPodInfoFilter
+
+	// Since only streams PipelineRuns created after now minus Since; zero means no limit
+	Since time.Duration
+
+	// MaxRuns bounds how many PipelineRuns will be streamed; 0 means unbounded
+	MaxRuns int
+
+	// TektonAPIVersion overrides the tekton.dev PipelineRun API version to use (v1alpha1 or
+	// v1beta1) instead of auto-detecting it via discovery
+	TektonAPIVersion string
+
+	// Stream configures how each PipelineRun's own stages are streamed
+	Stream StreamOptions
+}
+
+// pipelineRunMeta is the subset of a PipelineRun's metadata FollowAll needs to dedupe and
+// filter runs without caring which tekton.dev API version they were decoded as
+type pipelineRunMeta struct {
+	Name              string
+	UID               string
+	CreationTimestamp metav1.Time
+}
+
+// FollowAll runs an informer, for whichever tekton.dev API version is being served, over
+// PipelineRuns in ns and, for every Add/Update event whose PipelineRun matches opts.Filter,
+// spawns a goroutine that streams that run's stages to out using StreamStages. Runs are
+// deduplicated by PipelineRun UID, so an Update event (or informer resync) for a run already
+// being streamed never replays it. The set of seen UIDs is held in memory only, so a
+// restarted command streams every matching run it lists again. Each per-run goroutine is cancelled as soon as
+// that PipelineRun reaches a terminal phase. FollowAll blocks until ctx is cancelled.
+func FollowAll(ctx context.Context, kubeClient kubernetes.Interface, tektonClient tektonclient.Interface, jxClient versioned.Interface, ns string, out io.Writer, opts FollowAllOptions) error {
+	version, err := DetectAPIVersion(kubeClient, opts.TektonAPIVersion)
+	if err != nil {
+		return err
+	}
+
+	syncOut := &SyncWriter{Out: out}
+
+	var cutoff time.Time
+	if opts.Since > 0 {
+		cutoff = time.Now().Add(-opts.Since)
+	}
+
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	var runCount int32
+
+	handle := func(obj interface{}) {
+		meta, ok := pipelineRunMetaOf(obj)
+		if !ok {
+			return
+		}
+
+		mu.Lock()
+		alreadySeen := seen[meta.UID]
+		mu.Unlock()
+		if alreadySeen {
+			return
+		}
+
+		if !cutoff.IsZero() && meta.CreationTimestamp.Time.Before(cutoff) {
+			return
+		}
+		if opts.MaxRuns > 0 && int(atomic.LoadInt32(&runCount)) >= opts.MaxRuns {
+			return
+		}
+
+		pri, err := CreatePipelineRunInfoForVersion(kubeClient, tektonClient, jxClient, ns, meta.Name, version)
+		if err != nil {
+			log.Warnf("failed to load PipelineRun %s: %s\n", meta.Name, err)
+			return
+		}
+		if !opts.Filter.BuildMatches(pri) {
+			return
+		}
+		if opts.MaxRuns > 0 && int(atomic.AddInt32(&runCount, 1)) > opts.MaxRuns {
+			return
+		}
+
+		// Only now are we committed to streaming this run, so only now record it as seen:
+		// marking it earlier would mean a transient error above (e.g. CreatePipelineRunInfo
+		// failing) permanently drops the run, since the next Update event would never retry it.
+		mu.Lock()
+		alreadySeen = seen[meta.UID]
+		seen[meta.UID] = true
+		mu.Unlock()
+		if alreadySeen {
+			return
+		}
+
+		log.Infof("streaming logs for %s\n", util.ColorInfo(meta.Name))
+		go followPipelineRun(ctx, kubeClient, tektonClient, ns, meta.Name, pri, syncOut, opts.Stream, version)
+	}
+
+	listWatch, expectedObj := listWatchForVersion(tektonClient, ns, version)
+	informer := cache.NewSharedInformer(listWatch, expectedObj, 30*time.Second)
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    handle,
+		UpdateFunc: func(old, newObj interface{}) { handle(newObj) },
+	})
+
+	stopCh := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		close(stopCh)
+	}()
+	informer.Run(stopCh)
+	return nil
+}
+
+// listWatchForVersion builds the ListWatch (and the empty object the informer decodes into)
+// for whichever tekton.dev API version is being served
+func listWatchForVersion(tektonClient tektonclient.Interface, ns string, version APIVersion) (*cache.ListWatch, runtime.Object) {
+	if version == APIVersionV1Beta1 {
+		return &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return tektonClient.TektonV1beta1().PipelineRuns(ns).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return tektonClient.TektonV1beta1().PipelineRuns(ns).Watch(options)
+			},
+		}, &tektonv1beta1.PipelineRun{}
+	}
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return tektonClient.TektonV1alpha1().PipelineRuns(ns).List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return tektonClient.TektonV1alpha1().PipelineRuns(ns).Watch(options)
+		},
+	}, &tektonv1alpha1.PipelineRun{}
+}
+
+// pipelineRunMetaOf extracts pipelineRunMeta from whichever tekton.dev API version object
+// the informer handed back
+func pipelineRunMetaOf(obj interface{}) (pipelineRunMeta, bool) {
+	switch pr := obj.(type) {
+	case *tektonv1alpha1.PipelineRun:
+		return pipelineRunMeta{Name: pr.Name, UID: string(pr.UID), CreationTimestamp: pr.CreationTimestamp}, true
+	case *tektonv1beta1.PipelineRun:
+		return pipelineRunMeta{Name: pr.Name, UID: string(pr.UID), CreationTimestamp: pr.CreationTimestamp}, true
+	default:
+		return pipelineRunMeta{}, false
+	}
+}
+
+// followPipelineRun streams a single PipelineRun's stages until it completes or ctx is cancelled
+func followPipelineRun(ctx context.Context, kubeClient kubernetes.Interface, tektonClient tektonclient.Interface, ns string, name string, pri *PipelineRunInfo, out io.Writer, streamOpts StreamOptions, version APIVersion) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		ticker := time.NewTicker(terminationPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				if pipelineRunPhaseForVersion(tektonClient, ns, name, version) != "" {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	if err := StreamStages(runCtx, kubeClient, tektonClient, ns, pri, out, streamOpts); err != nil {
+		log.Warnf("error streaming logs for %s: %s\n", name, err)
+	}
+}
+
+// pipelineRunPhaseForVersion fetches name and returns its terminal phase ("Succeeded",
+// "Failed" or "" if it hasn't finished), using whichever tekton.dev API version is being
+// served. Fetch errors are treated as "not finished yet" so a transient API hiccup doesn't
+// prematurely stop the follower.
+func pipelineRunPhaseForVersion(tektonClient tektonclient.Interface, ns string, name string, version APIVersion) string {
+	if version == APIVersionV1Beta1 {
+		pr, err := tektonClient.TektonV1beta1().PipelineRuns(ns).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return ""
+		}
+		return pipelineRunPhaseV1beta1(pr)
+	}
+	pr, err := tektonClient.TektonV1alpha1().PipelineRuns(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return ""
+	}
+	return pipelineRunPhase(pr)
+}
+
+func pipelineRunPhaseV1beta1(pr *tektonv1beta1.PipelineRun) string {
+	for _, cond := range pr.Status.Conditions {
+		if string(cond.Type) != "Succeeded" {
+			continue
+		}
+		switch string(cond.Status) {
+		case "True":
+			return "Succeeded"
+		case "False":
+			return "Failed"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,177 @@
+package tekton
+
+import (
+	"context"
+
+	"github.com/jenkins-x/jx/pkg/builds"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	tektonv1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+)
+
+// LogPersistController watches PipelineRuns and, once one reaches a terminal phase,
+// archives its init container logs and a manifest of stages/exit codes to a LogStore
+// before the pod (and its emptyDir-backed logs) is garbage collected
+type LogPersistController struct {
+	KubeClient   kubernetes.Interface
+	TektonClient tektonclient.Interface
+	JXClient     versioned.Interface
+	Namespace    string
+	Store        builds.LogStore
+
+	// TektonAPIVersion overrides the tekton.dev PipelineRun API version to use (v1alpha1 or
+	// v1beta1) instead of auto-detecting it via discovery
+	TektonAPIVersion string
+
+	archived map[string]bool
+}
+
+// NewLogPersistController creates a controller which archives completed PipelineRun logs to store
+func NewLogPersistController(kubeClient kubernetes.Interface, tektonClient tektonclient.Interface, jxClient versioned.Interface, ns string, store builds.LogStore) *LogPersistController {
+	return &LogPersistController{
+		KubeClient:   kubeClient,
+		TektonClient: tektonClient,
+		JXClient:     jxClient,
+		Namespace:    ns,
+		Store:        store,
+		archived:     map[string]bool{},
+	}
+}
+
+// Run watches PipelineRuns until ctx is cancelled, re-establishing the watch whenever the
+// server closes it, and archives each one's logs at most once per controller process as
+// soon as it reaches a terminal (Succeeded/Failed) phase; a failed archive is logged and
+// not retried
+func (c *LogPersistController) Run(ctx context.Context) error {
+	version, err := DetectAPIVersion(c.KubeClient, c.TektonAPIVersion)
+	if err != nil {
+		return err
+	}
+
+	listWatch, _ := listWatchForVersion(c.TektonClient, c.Namespace, version)
+	watcher, err := listWatch.WatchFunc(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to watch PipelineRuns")
+	}
+	defer func() { watcher.Stop() }()
+
+	for {
+		select {
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				watcher.Stop()
+				watcher, err = listWatch.WatchFunc(metav1.ListOptions{})
+				if err != nil {
+					return errors.Wrap(err, "failed to re-establish the PipelineRun watch")
+				}
+				continue
+			}
+			meta, ok := pipelineRunMetaOf(event.Object)
+			if !ok {
+				continue
+			}
+			if c.archived[meta.Name] || pipelineRunPhaseOf(event.Object) == "" {
+				continue
+			}
+			c.archived[meta.Name] = true
+			if err := c.archive(meta.Name, version); err != nil {
+				log.Warnf("failed to archive logs for PipelineRun %s: %s\n", meta.Name, err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// archive reads every init container's log for the named PipelineRun and uploads them,
+// plus a manifest describing the stages/exit codes, to the configured LogStore
+func (c *LogPersistController) archive(name string, version APIVersion) error {
+	pri, err := CreatePipelineRunInfoForVersion(c.KubeClient, c.TektonClient, c.JXClient, c.Namespace, name, version)
+	if err != nil {
+		return err
+	}
+
+	manifest := &builds.LogManifest{
+		Owner:      pri.Owner,
+		Repository: pri.Repository,
+		Branch:     pri.Branch,
+		Build:      pri.Build,
+	}
+
+	for _, stage := range pri.GetOrderedTaskStages() {
+		if err := stage.SetPodsForStageInfo(c.KubeClient, c.TektonClient, c.Namespace, pri.PipelineRun); err != nil {
+			return err
+		}
+		if stage.Pod == nil {
+			continue
+		}
+
+		manifestStage := builds.LogManifestStage{Name: stage.Name}
+		for _, ic := range stage.Pod.Spec.InitContainers {
+			if err := c.archiveContainer(pri, stage, ic); err != nil {
+				return err
+			}
+			manifestStage.Containers = append(manifestStage.Containers, builds.LogManifestContainer{
+				Name:     ic.Name,
+				ExitCode: exitCodeOf(stage.Pod, ic.Name),
+			})
+		}
+		manifest.Stages = append(manifest.Stages, manifestStage)
+	}
+
+	return c.Store.WriteManifest(pri.Owner, pri.Repository, pri.Branch, pri.Build, manifest)
+}
+
+func (c *LogPersistController) archiveContainer(pri *PipelineRunInfo, stage *StageInfo, container corev1.Container) error {
+	stream, err := c.KubeClient.CoreV1().Pods(c.Namespace).GetLogs(stage.Pod.Name, &corev1.PodLogOptions{Container: container.Name}).Stream()
+	if err != nil {
+		return errors.Wrapf(err, "failed to read log for container %s", container.Name)
+	}
+	defer stream.Close()
+
+	return c.Store.WriteLog(pri.Owner, pri.Repository, pri.Branch, pri.Build, stage.Name, container.Name, stream)
+}
+
+func exitCodeOf(pod *corev1.Pod, container string) int32 {
+	for _, status := range pod.Status.InitContainerStatuses {
+		if status.Name == container && status.State.Terminated != nil {
+			return status.State.Terminated.ExitCode
+		}
+	}
+	return 0
+}
+
+// pipelineRunPhaseOf returns the terminal phase ("Succeeded", "Failed" or "" if not finished)
+// of a watch event's object, whichever tekton.dev API version it was decoded as
+func pipelineRunPhaseOf(obj interface{}) string {
+	switch pr := obj.(type) {
+	case *tektonv1alpha1.PipelineRun:
+		return pipelineRunPhase(pr)
+	case *tektonv1beta1.PipelineRun:
+		return pipelineRunPhaseV1beta1(pr)
+	default:
+		return ""
+	}
+}
+
+func pipelineRunPhase(pr *tektonv1alpha1.PipelineRun) string {
+	for _, cond := range pr.Status.Conditions {
+		if string(cond.Type) != "Succeeded" {
+			continue
+		}
+		switch string(cond.Status) {
+		case "True":
+			return "Succeeded"
+		case "False":
+			return "Failed"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,146 @@
+package tekton
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/jenkins-x/jx/pkg/builds"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+)
+
+// PipelineRunInfo is the data we display about a Tekton PipelineRun, normalised from
+// whichever tekton.dev API version the cluster actually serves
+type PipelineRunInfo struct {
+	PipelineRun string
+	Pipeline    string
+	Build       string
+	Owner       string
+	Repository  string
+	Branch      string
+
+	stages []*StageInfo
+}
+
+// GetBuild returns the build number
+func (pr *PipelineRunInfo) GetBuild() string {
+	return pr.Build
+}
+
+// GetPipeline returns the pipeline name in the form owner/repository/branch
+func (pr *PipelineRunInfo) GetPipeline() string {
+	return pr.Pipeline
+}
+
+// GetOwner returns the owner (person/organisation) of the repository which triggered the build
+func (pr *PipelineRunInfo) GetOwner() string {
+	return pr.Owner
+}
+
+// GetRepository returns the repository which triggered the build
+func (pr *PipelineRunInfo) GetRepository() string {
+	return pr.Repository
+}
+
+// GetBranch returns the branch which triggered the build
+func (pr *PipelineRunInfo) GetBranch() string {
+	return pr.Branch
+}
+
+// GetOrderedTaskStages returns the stages of the pipeline in the order they were declared
+func (pr *PipelineRunInfo) GetOrderedTaskStages() []*StageInfo {
+	return pr.stages
+}
+
+// StageInfo is the data we display about a single Task/stage of a PipelineRun
+type StageInfo struct {
+	Name     string
+	TaskName string
+	RunAfter []string
+	Pod      *corev1.Pod
+
+	parent *StageInfo
+}
+
+// GetStageNameIncludingParents returns the stage name prefixed by any parent stage names,
+// separated by "/", so that nested/synthetic stages are displayed unambiguously
+func (s *StageInfo) GetStageNameIncludingParents() string {
+	if s.parent == nil {
+		return s.Name
+	}
+	return s.parent.GetStageNameIncludingParents() + "/" + s.Name
+}
+
+// SetPodsForStageInfo looks up and assigns the Pod backing this stage's TaskRun, if it has started
+func (s *StageInfo) SetPodsForStageInfo(kubeClient kubernetes.Interface, tektonClient tektonclient.Interface, ns string, pipelineRunName string) error {
+	podList, err := kubeClient.CoreV1().Pods(ns).List(metav1.ListOptions{
+		LabelSelector: "tekton.dev/pipelineRun=" + pipelineRunName,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list pods for PipelineRun %s", pipelineRunName)
+	}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Labels["tekton.dev/task"] == s.TaskName {
+			s.Pod = pod
+			return nil
+		}
+	}
+	return nil
+}
+
+// CreatePipelineRunInfo creates a PipelineRunInfo from a v1alpha1 PipelineRun, resolving the
+// stages from pr.Spec.PipelineSpec if it's set, otherwise from the Pipeline referenced by
+// pr.Spec.PipelineRef
+func CreatePipelineRunInfo(kubeClient kubernetes.Interface, tektonClient tektonclient.Interface, jxClient versioned.Interface, ns string, name string) (*PipelineRunInfo, error) {
+	pr, err := tektonClient.TektonV1alpha1().PipelineRuns(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load PipelineRun %s", name)
+	}
+
+	pipeline := pr.Labels[builds.LabelPipeline]
+	owner, repository, branch := builds.SplitPipelineName(pipeline)
+
+	info := &PipelineRunInfo{
+		PipelineRun: pr.Name,
+		Pipeline:    pipeline,
+		Build:       pr.Labels[builds.LabelBuildNumber],
+		Owner:       owner,
+		Repository:  repository,
+		Branch:      branch,
+	}
+
+	spec := pr.Spec.PipelineSpec
+	if spec == nil && pr.Spec.PipelineRef != nil {
+		referencedPipeline, err := tektonClient.TektonV1alpha1().Pipelines(ns).Get(pr.Spec.PipelineRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load Pipeline %s referenced by PipelineRun %s", pr.Spec.PipelineRef.Name, name)
+		}
+		spec = &referencedPipeline.Spec
+	}
+	if spec == nil {
+		return info, nil
+	}
+	for _, task := range spec.Tasks {
+		info.stages = append(info.stages, &StageInfo{
+			Name:     task.Name,
+			TaskName: task.Name,
+			RunAfter: task.RunAfter,
+		})
+	}
+	return info, nil
+}
+
+// SortPipelineRunInfos sorts the given PipelineRunInfos with the most recent build first
+func SortPipelineRunInfos(infos []*PipelineRunInfo) {
+	sort.Slice(infos, func(i, j int) bool {
+		bi, _ := strconv.Atoi(infos[i].Build)
+		bj, _ := strconv.Atoi(infos[j].Build)
+		return bi > bj
+	})
+}
@@ -0,0 +1,235 @@
+package tekton
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+)
+
+// stagePodPollInterval is how often we re-check whether a stage's pod has appeared yet
+const stagePodPollInterval = time.Second
+
+// SyncWriter wraps an io.Writer with a mutex so that concurrent writers - e.g. several
+// PipelineRuns streamed at once via --follow-all - never interleave a single line's bytes
+type SyncWriter struct {
+	mu  sync.Mutex
+	Out io.Writer
+}
+
+// Write implements io.Writer
+func (w *SyncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.Out.Write(p)
+}
+
+// StreamOptions configures how parallel stage logs are multiplexed to a writer
+type StreamOptions struct {
+	// Prefix controls whether each log line is prefixed with "[stage/container]"
+	Prefix bool
+
+	// Color controls whether the prefix is colorized
+	Color bool
+
+	// MaxConcurrency bounds how many stages are tailed at once; 0 means unbounded
+	MaxConcurrency int
+
+	// PodWaitTimeout bounds how long to wait for each stage's pod to start; 0 means wait
+	// indefinitely (bounded only by ctx)
+	PodWaitTimeout time.Duration
+}
+
+// deadlineChan returns a channel that fires once after timeout, and a matching stop
+// function to release its timer. If timeout <= 0 the channel never fires, so whatever
+// select it guards is bounded only by ctx.
+func deadlineChan(timeout time.Duration) (<-chan time.Time, func()) {
+	if timeout <= 0 {
+		return nil, func() {}
+	}
+	timer := time.NewTimer(timeout)
+	return timer.C, func() { timer.Stop() }
+}
+
+// StreamStages builds the DAG of pr's stages from their RunAfter edges and tails every
+// init container of every stage concurrently, only opening a reader for a stage once its
+// RunAfter dependencies' pods have themselves started (not once they've finished tailing,
+// which would serialize parallel branches). Output lines are multiplexed through a single
+// mutex-guarded writer with a "[stage/container]" prefix so interleaved output from parallel
+// stages never tears mid-line. It returns once every stage has finished tailing, or as soon
+// as possible after ctx is cancelled.
+func StreamStages(ctx context.Context, kubeClient kubernetes.Interface, tektonClient tektonclient.Interface, ns string, pr *PipelineRunInfo, out io.Writer, opts StreamOptions) error {
+	stages := pr.GetOrderedTaskStages()
+	if len(stages) == 0 {
+		return nil
+	}
+
+	podStarted := make(map[string]chan struct{}, len(stages))
+	for _, s := range stages {
+		podStarted[s.Name] = make(chan struct{})
+	}
+
+	maxConcurrency := opts.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = len(stages)
+	}
+	sem := make(chan struct{}, maxConcurrency)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(stages))
+
+	for _, s := range stages {
+		s := s
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if !waitForDeps(ctx, s, podStarted) {
+				close(podStarted[s.Name])
+				return
+			}
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				close(podStarted[s.Name])
+				return
+			}
+			defer func() { <-sem }()
+
+			if err := tailStage(ctx, kubeClient, tektonClient, ns, pr, s, out, &mu, opts, podStarted[s.Name]); err != nil {
+				errCh <- err
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func waitForDeps(ctx context.Context, stage *StageInfo, podStarted map[string]chan struct{}) bool {
+	for _, dep := range stage.RunAfter {
+		depStarted, ok := podStarted[dep]
+		if !ok {
+			continue
+		}
+		select {
+		case <-depStarted:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// tailStage waits for stage's pod, closing podStarted as soon as that wait resolves - whether
+// the pod actually started, the wait was cancelled, or it failed - so that dependent stages
+// waiting on podStarted are never blocked on this stage's (potentially long-running) log tail.
+func tailStage(ctx context.Context, kubeClient kubernetes.Interface, tektonClient tektonclient.Interface, ns string, pr *PipelineRunInfo, stage *StageInfo, out io.Writer, mu *sync.Mutex, opts StreamOptions, podStarted chan struct{}) error {
+	err := waitForStagePod(ctx, kubeClient, tektonClient, ns, pr, stage, opts.PodWaitTimeout)
+	close(podStarted)
+	if err != nil {
+		return err
+	}
+	if stage.Pod == nil {
+		return nil
+	}
+	for _, container := range stage.Pod.Spec.InitContainers {
+		if err := tailContainer(ctx, kubeClient, ns, stage, container, out, mu, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitForStagePod polls until stage's pod appears, ctx is cancelled, or timeout elapses
+// (timeout <= 0 means wait indefinitely, bounded only by ctx)
+func waitForStagePod(ctx context.Context, kubeClient kubernetes.Interface, tektonClient tektonclient.Interface, ns string, pr *PipelineRunInfo, stage *StageInfo, timeout time.Duration) error {
+	deadline, stop := deadlineChan(timeout)
+	defer stop()
+
+	for stage.Pod == nil {
+		if err := stage.SetPodsForStageInfo(kubeClient, tektonClient, ns, pr.PipelineRun); err != nil {
+			return err
+		}
+		if stage.Pod != nil {
+			return nil
+		}
+		select {
+		case <-time.After(stagePodPollInterval):
+		case <-ctx.Done():
+			return nil
+		case <-deadline:
+			return fmt.Errorf("timed out after %s waiting for stage %s's pod to start", timeout, stage.GetStageNameIncludingParents())
+		}
+	}
+	return nil
+}
+
+func tailContainer(ctx context.Context, kubeClient kubernetes.Interface, ns string, stage *StageInfo, container corev1.Container, out io.Writer, mu *sync.Mutex, opts StreamOptions) error {
+	req := kubeClient.CoreV1().Pods(ns).GetLogs(stage.Pod.Name, &corev1.PodLogOptions{
+		Container: container.Name,
+		Follow:    true,
+	})
+	stream, err := req.Stream()
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	// Scan() only checks ctx between completed lines, so it won't unblock on its own if
+	// it's parked waiting for more bytes from an idle but still-open Follow stream; force
+	// the stream closed as soon as ctx is cancelled so Scan() returns promptly.
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			stream.Close()
+		case <-done:
+		}
+	}()
+	defer close(done)
+
+	prefix := linePrefix(stage, container.Name, opts)
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		mu.Lock()
+		fmt.Fprintf(out, "%s%s\n", prefix, scanner.Text())
+		mu.Unlock()
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
+
+func linePrefix(stage *StageInfo, container string, opts StreamOptions) string {
+	if !opts.Prefix {
+		return ""
+	}
+	label := fmt.Sprintf("[%s/%s] ", stage.GetStageNameIncludingParents(), container)
+	if !opts.Color {
+		return label
+	}
+	return fmt.Sprintf("%s", util.ColorInfo(label))
+}
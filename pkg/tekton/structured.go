@@ -0,0 +1,282 @@
+package tekton
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/util/backoff"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+)
+
+// Event is a single newline-delimited JSON record describing progress of a PipelineRun,
+// written by WriteJSONLog so downstream tools can consume `jx get build log` output
+// programmatically instead of scraping colorized text
+type Event struct {
+	Type      string `json:"type"`
+	Stage     string `json:"stage,omitempty"`
+	Container string `json:"container,omitempty"`
+	Line      string `json:"line,omitempty"`
+	ExitCode  *int32 `json:"exit_code,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	Phase     string `json:"phase,omitempty"`
+}
+
+const (
+	// EventStageStart marks a stage beginning to tail
+	EventStageStart = "stage_start"
+	// EventLog carries a single log line from a stage's container
+	EventLog = "log"
+	// EventStageEnd marks an init container within a stage terminating
+	EventStageEnd = "stage_end"
+	// EventRunResult is emitted once at the end, summarising the whole PipelineRun
+	EventRunResult = "run_result"
+)
+
+// containerStatusPrecedence ranks container/stage outcomes so the overall run phase can be
+// computed as the highest-ranked status across every stage: aborted > failed >
+// failed-negative > skipped > passed
+var containerStatusPrecedence = map[string]int{
+	"Aborted":        5,
+	"Failed":         4,
+	"FailedNegative": 3,
+	"Skipped":        2,
+	"Passed":         1,
+}
+
+// WriteJSONLog tails pr's stages in order, writing one JSON event per line to out, and
+// finishes with a single run_result event summarising the overall outcome. podWaitTimeout
+// bounds how long it waits for each stage's pod to start; 0 means wait indefinitely
+// (bounded only by ctx).
+func WriteJSONLog(ctx context.Context, kubeClient kubernetes.Interface, tektonClient tektonclient.Interface, ns string, pr *PipelineRunInfo, out io.Writer, podWaitTimeout time.Duration) error {
+	encoder := json.NewEncoder(out)
+	overall := "Passed"
+
+	for _, stage := range pr.GetOrderedTaskStages() {
+		if err := waitForStagePod(ctx, kubeClient, tektonClient, ns, pr, stage, podWaitTimeout); err != nil {
+			return err
+		}
+
+		stageName := stage.GetStageNameIncludingParents()
+		if err := encoder.Encode(Event{Type: EventStageStart, Stage: stageName}); err != nil {
+			return err
+		}
+
+		if stage.Pod == nil {
+			overall = maxStatus(overall, "Skipped")
+			continue
+		}
+
+		for _, container := range stage.Pod.Spec.InitContainers {
+			status, err := tailContainerAsJSON(ctx, kubeClient, ns, stage, container, stageName, encoder)
+			if err != nil {
+				return err
+			}
+			overall = maxStatus(overall, status)
+		}
+	}
+
+	return encoder.Encode(Event{Type: EventRunResult, Phase: runResultPhase(overall)})
+}
+
+// runResultPhase maps the internal per-container status vocabulary (which also tracks
+// "Skipped" and "FailedNegative" so maxStatus can rank them against each other) onto the
+// run_result phase consumers are documented to expect: Succeeded, Failed or Aborted
+func runResultPhase(overall string) string {
+	switch overall {
+	case "Aborted":
+		return "Aborted"
+	case "Failed", "FailedNegative":
+		return "Failed"
+	default:
+		return "Succeeded"
+	}
+}
+
+func tailContainerAsJSON(ctx context.Context, kubeClient kubernetes.Interface, ns string, stage *StageInfo, container corev1.Container, stageName string, encoder *json.Encoder) (string, error) {
+	req := kubeClient.CoreV1().Pods(ns).GetLogs(stage.Pod.Name, &corev1.PodLogOptions{
+		Container: container.Name,
+		Follow:    true,
+	})
+	stream, err := req.Stream()
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			stream.Close()
+		case <-done:
+		}
+	}()
+	defer close(done)
+
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		select {
+		case <-ctx.Done():
+			return "Aborted", nil
+		default:
+		}
+		if err := encoder.Encode(Event{Type: EventLog, Stage: stageName, Container: container.Name, Line: scanner.Text()}); err != nil {
+			return "", err
+		}
+	}
+	if ctx.Err() != nil {
+		return "Aborted", nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	pod, err := kubeClient.CoreV1().Pods(ns).Get(stage.Pod.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	term := terminatedStateOf(pod, container.Name)
+	status := statusOf(term)
+
+	exitCode, reason := int32(0), ""
+	if term != nil {
+		exitCode, reason = term.ExitCode, term.Reason
+	}
+	if err := encoder.Encode(Event{Type: EventStageEnd, Stage: stageName, Container: container.Name, ExitCode: &exitCode, Reason: reason}); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+func terminatedStateOf(pod *corev1.Pod, container string) *corev1.ContainerStateTerminated {
+	for _, status := range pod.Status.InitContainerStatuses {
+		if status.Name == container {
+			return status.State.Terminated
+		}
+	}
+	return nil
+}
+
+func statusOf(term *corev1.ContainerStateTerminated) string {
+	switch {
+	case term == nil:
+		return "Skipped"
+	case term.ExitCode == 0:
+		return "Passed"
+	case term.ExitCode < 0:
+		return "FailedNegative"
+	default:
+		return "Failed"
+	}
+}
+
+func maxStatus(a, b string) string {
+	if containerStatusPrecedence[b] > containerStatusPrecedence[a] {
+		return b
+	}
+	return a
+}
+
+// junitTestSuite is a minimal JUnit XML report, one testcase per stage/container
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnitReport waits for every init container in pr to terminate, then writes a JUnit
+// XML report (one testcase per stage/container) that CI systems can consume directly.
+// podWaitTimeout bounds how long it waits for each stage's pod to start; 0 means wait
+// indefinitely (bounded only by ctx).
+func WriteJUnitReport(ctx context.Context, kubeClient kubernetes.Interface, tektonClient tektonclient.Interface, ns string, pr *PipelineRunInfo, out io.Writer, podWaitTimeout time.Duration) error {
+	suite := junitTestSuite{Name: pr.Pipeline + " #" + pr.Build}
+
+	for _, stage := range pr.GetOrderedTaskStages() {
+		if err := waitForStagePod(ctx, kubeClient, tektonClient, ns, pr, stage, podWaitTimeout); err != nil {
+			return err
+		}
+		if stage.Pod == nil {
+			suite.Tests++
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      "(skipped)",
+				ClassName: stage.GetStageNameIncludingParents(),
+				Failure:   &junitFailure{Message: "stage never started"},
+			})
+			suite.Failures++
+			continue
+		}
+
+		for _, container := range stage.Pod.Spec.InitContainers {
+			term, err := waitForContainerTermination(ctx, kubeClient, ns, stage.Pod.Name, container.Name, podWaitTimeout)
+			if err != nil {
+				return err
+			}
+			suite.Tests++
+			testCase := junitTestCase{Name: container.Name, ClassName: stage.GetStageNameIncludingParents()}
+			if term == nil || term.ExitCode != 0 {
+				suite.Failures++
+				reason := "did not terminate"
+				exitCode := int32(0)
+				if term != nil {
+					reason, exitCode = term.Reason, term.ExitCode
+				}
+				testCase.Failure = &junitFailure{Message: fmt.Sprintf("exit code %d: %s", exitCode, reason)}
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+	}
+
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	return encoder.Encode(suite)
+}
+
+// waitForContainerTermination polls until container terminates, ctx is cancelled, or
+// timeout elapses (timeout <= 0 means wait indefinitely, bounded only by ctx)
+func waitForContainerTermination(ctx context.Context, kubeClient kubernetes.Interface, ns string, podName string, container string, timeout time.Duration) (*corev1.ContainerStateTerminated, error) {
+	deadline, stop := deadlineChan(timeout)
+	defer stop()
+
+	b := &backoff.Backoff{Min: 500 * time.Millisecond, Max: 5 * time.Second, Factor: 2, Jitter: true}
+	for {
+		pod, err := kubeClient.CoreV1().Pods(ns).Get(podName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if term := terminatedStateOf(pod, container); term != nil {
+			return term, nil
+		}
+		if pod.Status.Phase == corev1.PodFailed {
+			return nil, nil
+		}
+		select {
+		case <-time.After(b.Duration()):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline:
+			return nil, fmt.Errorf("timed out after %s waiting for container %s in pod %s to terminate", timeout, container, podName)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Package backoff implements exponential backoff with jitter, patterned after
+// jpillora/backoff, for code that needs to retry against a slow-starting API server
+// without hammering it or busy-looping on a flat sleep.
+package backoff
+
+import (
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// Backoff computes successive retry durations. The zero value is usable: Min defaults to
+// 100ms, Max to 10s and Factor to 2 the first time Duration is called.
+type Backoff struct {
+	// Min is the duration returned for the first attempt. Defaults to 100ms.
+	Min time.Duration
+
+	// Max is the ceiling no computed duration will exceed. Defaults to 10s.
+	Max time.Duration
+
+	// Factor is the multiplier applied per attempt. Defaults to 2.
+	Factor float64
+
+	// Jitter, if true, returns a uniformly random duration in [Min, d) instead of exactly d.
+	Jitter bool
+
+	attempt int64
+}
+
+// Duration returns the duration for the current attempt as
+// min(Min * Factor^attempt, Max), optionally randomised by Jitter, then advances the
+// attempt counter.
+func (b *Backoff) Duration() time.Duration {
+	attempt := atomic.AddInt64(&b.attempt, 1) - 1
+	return b.forAttempt(attempt)
+}
+
+func (b *Backoff) forAttempt(attempt int64) time.Duration {
+	min := b.Min
+	if min <= 0 {
+		min = 100 * time.Millisecond
+	}
+	max := b.Max
+	if max <= 0 {
+		max = 10 * time.Second
+	}
+	factor := b.Factor
+	if factor <= 0 {
+		factor = 2
+	}
+
+	d := float64(min) * math.Pow(factor, float64(attempt))
+	if d > float64(max) {
+		d = float64(max)
+	}
+	duration := time.Duration(d)
+
+	if b.Jitter && duration > min {
+		duration = min + time.Duration(rand.Int63n(int64(duration-min)))
+	}
+	return duration
+}
+
+// Reset sets the attempt counter back to zero so the next Duration() call returns Min again
+func (b *Backoff) Reset() {
+	atomic.StoreInt64(&b.attempt, 0)
+}
+
+// Attempt returns the number of times Duration has been called since creation or Reset
+func (b *Backoff) Attempt() int64 {
+	return atomic.LoadInt64(&b.attempt)
+}